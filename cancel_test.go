@@ -0,0 +1,57 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2012 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package mysql
+
+import (
+	"testing"
+	"time"
+)
+
+// waitForQueryInterrupt is the channel-selection logic at the heart of
+// killQuery's fallback/success decision; it needs no live server or
+// connector to exercise, just the three channels it selects on.
+
+func TestWaitForQueryInterruptSucceedsWhenFinished(t *testing.T) {
+	finished := make(chan struct{})
+	closech := make(chan struct{})
+
+	go close(finished)
+
+	if !waitForQueryInterrupt(finished, closech, time.Second) {
+		t.Fatal("expected true when finished fires before the timeout")
+	}
+}
+
+func TestWaitForQueryInterruptFailsWhenConnCloses(t *testing.T) {
+	finished := make(chan struct{})
+	closech := make(chan struct{})
+
+	go close(closech)
+
+	if waitForQueryInterrupt(finished, closech, time.Second) {
+		t.Fatal("expected false when closech fires before finished")
+	}
+}
+
+func TestWaitForQueryInterruptFailsOnTimeout(t *testing.T) {
+	finished := make(chan struct{})
+	closech := make(chan struct{})
+
+	if waitForQueryInterrupt(finished, closech, 10*time.Millisecond) {
+		t.Fatal("expected false when neither channel fires before the timeout")
+	}
+}
+
+func TestKillQueryWithoutConnectionIDIsANoOp(t *testing.T) {
+	mc := &mysqlConn{cfg: &Config{}}
+
+	if mc.killQuery() {
+		t.Fatal("expected killQuery to report false when connectionID is unset")
+	}
+}
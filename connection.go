@@ -41,11 +41,18 @@ type mysqlConn struct {
 	parseTime        bool
 	compress         bool
 
+	// connectionID is the server's connection id for this session, captured
+	// from the initial handshake packet by readHandshakePacket. It is used
+	// to issue a sideband "KILL QUERY <connectionID>" when
+	// Config.ServerSideCancel is enabled. Zero means it hasn't been
+	// observed yet.
+	connectionID uint32
+
 	// for context support (Go 1.8+)
 	watching bool
 	watcher  chan<- context.Context
 	closech  chan struct{}
-	finished chan<- struct{}
+	finished chan struct{}
 	canceled atomicError // set non-nil if conn is canceled
 	closed   atomic.Bool // set when conn is closed, before closech is closed
 }
@@ -120,7 +127,7 @@ func (mc *mysqlConn) handleParams() (err error) {
 	}
 
 	if cmdSet.Len() > 0 {
-		err = mc.exec(cmdSet.String())
+		err = mc.exec(cmdSet.String(), nil)
 	}
 
 	return
@@ -149,7 +156,7 @@ func (mc *mysqlConn) begin(readOnly bool) (driver.Tx, error) {
 	} else {
 		q = "START TRANSACTION"
 	}
-	err := mc.exec(q)
+	err := mc.exec(q, nil)
 	if err == nil {
 		return &mysqlTx{mc}, err
 	}
@@ -347,6 +354,12 @@ func (mc *mysqlConn) interpolateParams(query string, args []driver.Value) (strin
 }
 
 func (mc *mysqlConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	return mc.execWithAttrs(query, args, nil)
+}
+
+// execWithAttrs is Exec plus a set of MySQL 8.0 query attributes to send
+// alongside the COM_QUERY, for callers (ExecContext) that have some to send.
+func (mc *mysqlConn) execWithAttrs(query string, args []driver.Value, attrs []queryAttribute) (driver.Result, error) {
 	if mc.closed.Load() {
 		return nil, driver.ErrBadConn
 	}
@@ -363,7 +376,7 @@ func (mc *mysqlConn) Exec(query string, args []driver.Value) (driver.Result, err
 		query = prepared
 	}
 
-	err := mc.exec(query)
+	err := mc.exec(query, attrs)
 	if err == nil {
 		copied := mc.result
 		return &copied, err
@@ -373,10 +386,10 @@ func (mc *mysqlConn) Exec(query string, args []driver.Value) (driver.Result, err
 }
 
 // Internal function to execute commands
-func (mc *mysqlConn) exec(query string) error {
+func (mc *mysqlConn) exec(query string, attrs []queryAttribute) error {
 	handleOk := mc.clearResult()
 	// Send command
-	if err := mc.writeCommandPacketStr(comQuery, query); err != nil {
+	if err := mc.writeQueryPacket(query, attrs); err != nil {
 		mc.log(err.Error())
 		return mc.markBadConn(err)
 	}
@@ -406,10 +419,10 @@ func (mc *mysqlConn) exec(query string) error {
 }
 
 func (mc *mysqlConn) Query(query string, args []driver.Value) (driver.Rows, error) {
-	return mc.query(query, args)
+	return mc.query(query, args, nil)
 }
 
-func (mc *mysqlConn) query(query string, args []driver.Value) (*textRows, error) {
+func (mc *mysqlConn) query(query string, args []driver.Value, attrs []queryAttribute) (*textRows, error) {
 	handleOk := mc.clearResult()
 
 	if mc.closed.Load() {
@@ -428,7 +441,7 @@ func (mc *mysqlConn) query(query string, args []driver.Value) (*textRows, error)
 		query = prepared
 	}
 	// Send command
-	err := mc.writeCommandPacketStr(comQuery, query)
+	err := mc.writeQueryPacket(query, attrs)
 	if err != nil {
 		mc.log(err.Error())
 		return nil, mc.markBadConn(err)
@@ -495,9 +508,69 @@ func (mc *mysqlConn) getSystemVar(name string) ([]byte, error) {
 // cancel is called when the query has canceled.
 func (mc *mysqlConn) cancel(err error) {
 	mc.canceled.Set(err)
+
+	if mc.cfg.ServerSideCancel && mc.killQuery() {
+		// The server interrupted the running query for us, so the
+		// connection itself is still perfectly usable. Leave it open
+		// and let it return to the pool instead of discarding it.
+		return
+	}
+
 	mc.cleanup()
 }
 
+// serverSideCancelGracePeriod bounds how long killQuery waits for the
+// in-flight query to unwind with an ER_QUERY_INTERRUPTED error after a
+// sideband KILL QUERY has been sent, before giving up and letting the
+// caller fall back to cleanup().
+const serverSideCancelGracePeriod = 5 * time.Second
+
+// killQuery attempts to interrupt the query currently running on mc by
+// opening a short-lived sideband connection to the same server and issuing
+// KILL QUERY for mc's connection id. It reports whether the kill was
+// delivered and the original connection unwound in time, meaning mc can be
+// safely reused; any failure means the caller should fall back to tearing
+// mc down.
+func (mc *mysqlConn) killQuery() bool {
+	if mc.connectionID == 0 || mc.connector == nil {
+		return false
+	}
+
+	connectCtx, cancel := context.WithTimeout(context.Background(), serverSideCancelGracePeriod)
+	defer cancel()
+	sideConn, err := mc.connector.Connect(connectCtx)
+	if err != nil {
+		mc.log("server-side cancel: failed to open sideband connection:", err)
+		return false
+	}
+	side := sideConn.(*mysqlConn)
+	defer side.Close()
+
+	killStmt := "KILL QUERY " + strconv.FormatUint(uint64(mc.connectionID), 10)
+	if err := side.exec(killStmt, nil); err != nil {
+		mc.log("server-side cancel: KILL QUERY failed:", err)
+		return false
+	}
+
+	return waitForQueryInterrupt(mc.finished, mc.closech, serverSideCancelGracePeriod)
+}
+
+// waitForQueryInterrupt gives the goroutine running the killed query a
+// chance to observe ER_QUERY_INTERRUPTED and call finish() (signaled on
+// finished) before giving up. It reports whether finished fired in time;
+// closech firing or the timeout elapsing both mean the caller should fall
+// back to tearing the connection down.
+func waitForQueryInterrupt(finished, closech chan struct{}, timeout time.Duration) bool {
+	select {
+	case <-finished:
+		return true
+	case <-closech:
+		return false
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 // finish is called when the query has succeeded.
 func (mc *mysqlConn) finish() {
 	if !mc.watching || mc.finished == nil {
@@ -547,7 +620,7 @@ func (mc *mysqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver
 		if err != nil {
 			return nil, err
 		}
-		err = mc.exec("SET TRANSACTION ISOLATION LEVEL " + level)
+		err = mc.exec("SET TRANSACTION ISOLATION LEVEL "+level, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -557,6 +630,9 @@ func (mc *mysqlConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver
 }
 
 func (mc *mysqlConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	args, attrs := extractQueryAttributes(args)
+	attrs = append(attrs, queryAttrsFromContext(ctx)...)
+
 	dargs, err := namedValueToValue(args)
 	if err != nil {
 		return nil, err
@@ -566,7 +642,7 @@ func (mc *mysqlConn) QueryContext(ctx context.Context, query string, args []driv
 		return nil, err
 	}
 
-	rows, err := mc.query(query, dargs)
+	rows, err := mc.query(query, dargs, attrs)
 	if err != nil {
 		mc.finish()
 		return nil, err
@@ -576,6 +652,9 @@ func (mc *mysqlConn) QueryContext(ctx context.Context, query string, args []driv
 }
 
 func (mc *mysqlConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	args, attrs := extractQueryAttributes(args)
+	attrs = append(attrs, queryAttrsFromContext(ctx)...)
+
 	dargs, err := namedValueToValue(args)
 	if err != nil {
 		mc.log(err.Error())
@@ -588,7 +667,7 @@ func (mc *mysqlConn) ExecContext(ctx context.Context, query string, args []drive
 	}
 	defer mc.finish()
 
-	return mc.Exec(query, dargs)
+	return mc.execWithAttrs(query, dargs, attrs)
 }
 
 func (mc *mysqlConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
@@ -612,6 +691,13 @@ func (mc *mysqlConn) PrepareContext(ctx context.Context, query string) (driver.S
 }
 
 func (stmt *mysqlStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	args, attrs := extractQueryAttributes(args)
+	attrs = append(attrs, queryAttrsFromContext(ctx)...)
+
+	if len(attrs) > 0 {
+		return nil, ErrQueryAttributesUnsupported
+	}
+
 	dargs, err := namedValueToValue(args)
 	if err != nil {
 		return nil, err
@@ -631,6 +717,9 @@ func (stmt *mysqlStmt) QueryContext(ctx context.Context, args []driver.NamedValu
 }
 
 func (stmt *mysqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	args, attrs := extractQueryAttributes(args)
+	attrs = append(attrs, queryAttrsFromContext(ctx)...)
+
 	dargs, err := namedValueToValue(args)
 	if err != nil {
 		return nil, err
@@ -641,6 +730,9 @@ func (stmt *mysqlStmt) ExecContext(ctx context.Context, args []driver.NamedValue
 	}
 	defer stmt.mc.finish()
 
+	if len(attrs) > 0 {
+		return nil, ErrQueryAttributesUnsupported
+	}
 	return stmt.Exec(dargs)
 }
 
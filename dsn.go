@@ -0,0 +1,243 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2012 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package mysql
+
+import (
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidDSN is returned by ParseDSN when a data source name doesn't
+// match the expected "[user[:password]@][net[(addr)]]/dbname[?param=value]"
+// shape.
+var ErrInvalidDSN = errors.New("mysql: invalid DSN")
+
+// Config holds the configuration for a connection. It can be prepared
+// manually or by parsing a DSN string with ParseDSN. NewConfig returns a
+// Config filled with defaults; a zero-value Config is not valid on its own.
+type Config struct {
+	User   string // Username
+	Passwd string // Password (requires User)
+	Net    string // Network type
+	Addr   string // Network address (requires Net)
+	DBName string // Database name
+
+	Params map[string]string // Connection parameters set via "SET ..." after connecting
+	Loc    *time.Location    // Location for time.Time values
+
+	Logger *log.Logger // Logger used for connection-level warnings
+
+	ReadTimeout  time.Duration // I/O read timeout
+	WriteTimeout time.Duration // I/O write timeout
+	timeTruncate time.Duration // Truncation mode for time.Time values
+
+	InterpolateParams bool // Interpolate placeholders into the query string
+	CheckConnLiveness bool // Check connections for liveness before using them
+
+	// ServerSideCancel opts a connection into sideband cancellation: when a
+	// query's context is canceled, the driver opens a short-lived second
+	// connection and issues KILL QUERY for the original connection's id
+	// instead of tearing the original connection down. This keeps the
+	// connection in the pool when the server interrupts the query in time;
+	// see mysqlConn.killQuery.
+	ServerSideCancel bool
+}
+
+// NewConfig returns a new Config with the default values set.
+func NewConfig() *Config {
+	return &Config{
+		Loc:    time.UTC,
+		Logger: log.New(os.Stderr, "[mysql] ", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// FormatDSN formats the given Config into a DSN string which can be passed
+// to the driver.
+func (cfg *Config) FormatDSN() string {
+	var buf strings.Builder
+
+	if cfg.User != "" {
+		buf.WriteString(cfg.User)
+		if cfg.Passwd != "" {
+			buf.WriteByte(':')
+			buf.WriteString(cfg.Passwd)
+		}
+		buf.WriteByte('@')
+	}
+
+	if cfg.Net != "" {
+		buf.WriteString(cfg.Net)
+		if cfg.Addr != "" {
+			buf.WriteByte('(')
+			buf.WriteString(cfg.Addr)
+			buf.WriteByte(')')
+		}
+	}
+
+	buf.WriteByte('/')
+	buf.WriteString(cfg.DBName)
+
+	query := url.Values{}
+	if cfg.InterpolateParams {
+		query.Set("interpolateParams", "true")
+	}
+	if cfg.CheckConnLiveness {
+		query.Set("checkConnLiveness", "true")
+	}
+	if cfg.ServerSideCancel {
+		query.Set("serverSideCancel", "true")
+	}
+	if cfg.ReadTimeout > 0 {
+		query.Set("readTimeout", cfg.ReadTimeout.String())
+	}
+	if cfg.WriteTimeout > 0 {
+		query.Set("writeTimeout", cfg.WriteTimeout.String())
+	}
+	if cfg.Loc != nil && cfg.Loc != time.UTC {
+		query.Set("loc", cfg.Loc.String())
+	}
+	for param, val := range cfg.Params {
+		query.Set(param, val)
+	}
+
+	if encoded := query.Encode(); encoded != "" {
+		buf.WriteByte('?')
+		buf.WriteString(encoded)
+	}
+
+	return buf.String()
+}
+
+// ParseDSN parses the DSN string to a Config. It returns ErrInvalidDSN if
+// the string doesn't match "[user[:password]@][net[(addr)]]/dbname[?param=value]".
+func ParseDSN(dsn string) (*Config, error) {
+	cfg := NewConfig()
+
+	// [user[:password]@][net[(addr)]]/dbname[?param1=value1&paramN=valueN]
+	// Find the last '/' (since passwords or params may contain '@').
+	foundSlash := false
+	for i := len(dsn) - 1; i >= 0; i-- {
+		if dsn[i] != '/' {
+			continue
+		}
+		foundSlash = true
+
+		var j, k int
+
+		// left part is empty if i <= 0
+		if i > 0 {
+			// [username[:password]@][protocol[(address)]]
+			// Find the last '@' in dsn[:i]
+			for j = i; j >= 0; j-- {
+				if dsn[j] == '@' {
+					// username[:password]
+					// Find the first ':' in dsn[:j]
+					for k = 0; k < j; k++ {
+						if dsn[k] == ':' {
+							cfg.Passwd = dsn[k+1 : j]
+							break
+						}
+					}
+					cfg.User = dsn[:k]
+
+					break
+				}
+			}
+
+			// [protocol[(address)]]
+			// Find the first '(' in dsn[j+1:i]
+			for k = j + 1; k < i; k++ {
+				if dsn[k] == '(' {
+					// dsn[i-1] must be == ')' if an address is specified
+					if dsn[i-1] != ')' {
+						if strings.ContainsRune(dsn[k+1:i], ')') {
+							return nil, ErrInvalidDSN
+						}
+						return nil, ErrInvalidDSN
+					}
+					cfg.Addr = dsn[k+1 : i-1]
+					break
+				}
+			}
+			cfg.Net = dsn[j+1 : k]
+		}
+
+		// dbname[?param1=value1&...&paramN=valueN]
+		// Find the first '?' in dsn[i+1:]
+		for j = i + 1; j < len(dsn); j++ {
+			if dsn[j] == '?' {
+				if err := parseDSNParams(cfg, dsn[j+1:]); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+		cfg.DBName = dsn[i+1 : j]
+
+		break
+	}
+
+	if !foundSlash && len(dsn) > 0 {
+		return nil, ErrInvalidDSN
+	}
+
+	if cfg.Net == "" {
+		cfg.Net = "tcp"
+	}
+
+	return cfg, nil
+}
+
+// parseDSNParams parses the DSN "query string" part, assigning the known
+// parameters onto cfg and stashing everything else in cfg.Params so it can
+// be sent to the server as a session variable by handleParams.
+func parseDSNParams(cfg *Config, params string) (err error) {
+	for _, v := range strings.Split(params, "&") {
+		key, value, found := strings.Cut(v, "=")
+		if !found {
+			continue
+		}
+
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "interpolateParams":
+			cfg.InterpolateParams, err = strconv.ParseBool(value)
+		case "checkConnLiveness":
+			cfg.CheckConnLiveness, err = strconv.ParseBool(value)
+		case "serverSideCancel":
+			cfg.ServerSideCancel, err = strconv.ParseBool(value)
+		case "readTimeout":
+			cfg.ReadTimeout, err = time.ParseDuration(value)
+		case "writeTimeout":
+			cfg.WriteTimeout, err = time.ParseDuration(value)
+		case "loc":
+			cfg.Loc, err = time.LoadLocation(value)
+		default:
+			if cfg.Params == nil {
+				cfg.Params = make(map[string]string)
+			}
+			cfg.Params[key] = value
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2012 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package mysql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDSNBasic(t *testing.T) {
+	cfg, err := ParseDSN("user:pass@tcp(127.0.0.1:3306)/dbname")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.User != "user" || cfg.Passwd != "pass" || cfg.Net != "tcp" ||
+		cfg.Addr != "127.0.0.1:3306" || cfg.DBName != "dbname" {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestParseDSNDefaultsNetToTCP(t *testing.T) {
+	cfg, err := ParseDSN("user:pass@/dbname")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Net != "tcp" {
+		t.Fatalf("got net %q, want tcp", cfg.Net)
+	}
+}
+
+func TestParseDSNServerSideCancel(t *testing.T) {
+	cfg, err := ParseDSN("user:pass@tcp(127.0.0.1:3306)/dbname?serverSideCancel=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.ServerSideCancel {
+		t.Fatal("expected ServerSideCancel to be true")
+	}
+}
+
+func TestParseDSNServerSideCancelDefaultsFalse(t *testing.T) {
+	cfg, err := ParseDSN("user:pass@tcp(127.0.0.1:3306)/dbname")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ServerSideCancel {
+		t.Fatal("expected ServerSideCancel to default to false")
+	}
+}
+
+func TestParseDSNTimeoutsAndUnknownParams(t *testing.T) {
+	cfg, err := ParseDSN("user:pass@tcp(127.0.0.1:3306)/dbname?readTimeout=2s&writeTimeout=3s&sql_mode=TRADITIONAL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ReadTimeout != 2*time.Second || cfg.WriteTimeout != 3*time.Second {
+		t.Fatalf("unexpected timeouts: %+v", cfg)
+	}
+	if cfg.Params["sql_mode"] != "TRADITIONAL" {
+		t.Fatalf("expected unknown param to land in Params, got %+v", cfg.Params)
+	}
+}
+
+func TestParseDSNRejectsMissingSlash(t *testing.T) {
+	if _, err := ParseDSN("user:pass@tcp(127.0.0.1:3306)dbname"); err != ErrInvalidDSN {
+		t.Fatalf("expected ErrInvalidDSN, got %v", err)
+	}
+}
+
+func TestFormatDSNRoundTripsServerSideCancel(t *testing.T) {
+	cfg := NewConfig()
+	cfg.User = "user"
+	cfg.Passwd = "pass"
+	cfg.Net = "tcp"
+	cfg.Addr = "127.0.0.1:3306"
+	cfg.DBName = "dbname"
+	cfg.ServerSideCancel = true
+
+	roundTripped, err := ParseDSN(cfg.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !roundTripped.ServerSideCancel {
+		t.Fatal("expected ServerSideCancel to survive a FormatDSN/ParseDSN round trip")
+	}
+}
@@ -0,0 +1,86 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2012 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package mysql
+
+import "bytes"
+
+// readHandshakePacket parses the server's initial handshake packet
+// (Protocol::HandshakeV10), sent before authentication. Besides the
+// auth-plugin data returned to the caller to continue the auth exchange, it
+// records on mc:
+//
+//   - connectionID, the server's connection id, later used to issue a
+//     sideband "KILL QUERY <connectionID>" for Config.ServerSideCancel.
+//   - capabilities, the full set of (bit 0-31) flags the server advertised.
+//   - extCapabilities, the server's advertised extended (bit 33-64) flags
+//     ANDed with requestedExtCapabilities, so it only ever reflects
+//     capabilities this driver actually asks for in its HandshakeResponse
+//     (e.g. writeQueryPacket's CLIENT_QUERY_ATTRIBUTES check never fires on
+//     a flag the server advertised but this driver never requested).
+func (mc *mysqlConn) readHandshakePacket() (authData []byte, plugin string, err error) {
+	data, err := mc.readPacket()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// server_version [null-terminated string], right after the single
+	// protocol_version byte.
+	pos := 1 + bytes.IndexByte(data[1:], 0) + 1
+
+	// connection_id [4 bytes]
+	mc.connectionID = uint32(data[pos]) | uint32(data[pos+1])<<8 |
+		uint32(data[pos+2])<<16 | uint32(data[pos+3])<<24
+	pos += 4
+
+	// auth-plugin-data-part-1 [8 bytes]
+	authData = data[pos : pos+8]
+	pos += 8 + 1 // + filler byte, always 0x00
+
+	// capability flags (lower 2 bytes) [2 bytes]
+	capabilities := uint32(data[pos]) | uint32(data[pos+1])<<8
+	pos += 2
+
+	if len(data) > pos {
+		// character_set [1 byte], status_flags [2 bytes]
+		pos += 1 + 2
+
+		// capability flags (upper 2 bytes) [2 bytes]
+		capabilities |= uint32(data[pos])<<16 | uint32(data[pos+1])<<24
+		pos += 2
+
+		// length of auth-plugin-data [1 byte]
+		authDataLen := int(data[pos])
+		pos++
+
+		// reserved (all zero) [10 bytes]. MySQL 8.0 repurposes the first
+		// two of these bytes to advertise capability flags beyond bit 31
+		// (e.g. CLIENT_QUERY_ATTRIBUTES), which this driver tracks
+		// separately as extCapabilities, narrowed to what this driver
+		// actually requests so an unrequested server-advertised flag can
+		// never be mistaken for a negotiated one.
+		serverExtCapabilities := extendedCapabilityFlag(uint32(data[pos]) | uint32(data[pos+1])<<8)
+		mc.extCapabilities = serverExtCapabilities & requestedExtCapabilities
+		pos += 10
+
+		if authDataLen > 8 {
+			authData = append(authData, data[pos:pos+authDataLen-8]...)
+			pos += authDataLen - 8
+		}
+
+		if end := bytes.IndexByte(data[pos:], 0); end >= 0 {
+			plugin = string(data[pos : pos+end])
+		} else {
+			plugin = string(data[pos:])
+		}
+	}
+
+	mc.capabilities = capabilityFlag(capabilities)
+
+	return authData, plugin, nil
+}
@@ -0,0 +1,263 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2012 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrQueryAttributesUnsupported is returned by a prepared statement's
+// QueryContext/ExecContext when the caller supplied query attributes (via
+// an "@@attr:"-named argument or WithQueryAttributes) but the statement
+// execute path doesn't yet thread them into COM_STMT_EXECUTE. Callers get
+// an explicit error rather than having their attributes silently dropped;
+// use Conn.QueryContext/ExecContext directly (COM_QUERY) if you need them.
+var ErrQueryAttributesUnsupported = errors.New("mysql: query attributes are not supported for prepared statements")
+
+// clientQueryAttributes corresponds to MySQL 8.0.23's CLIENT_QUERY_ATTRIBUTES
+// capability, which lets a client attach named key/value metadata to a
+// COM_QUERY or COM_STMT_EXECUTE. The server exposes attributes through
+// performance_schema and mysql_query_attribute_string(). On connections that
+// didn't negotiate it, the COM_QUERY path (writeQueryPacket) silently drops
+// any attributes instead of failing.
+const clientQueryAttributes extendedCapabilityFlag = 1 << 0
+
+// requestedExtCapabilities is the full set of extended (bit 33-64)
+// capability flags this driver asks for in its HandshakeResponse.
+// readHandshakePacket ANDs the server's advertised extended capabilities
+// against this set, so mc.extCapabilities ends up holding only what both
+// sides agreed on -- in particular, writeQueryPacket only sends query
+// attributes once that negotiation has actually happened.
+const requestedExtCapabilities = clientCacheMetadata | clientQueryAttributes
+
+// queryAttrPrefix marks a driver.NamedValue as a query attribute rather than
+// a bound parameter. A NamedValue named "@@attr:trace_id" is stripped from
+// the argument list and sent to the server as a query attribute named
+// "trace_id".
+const queryAttrPrefix = "@@attr:"
+
+// queryAttribute is a single named MySQL 8.0 query attribute.
+type queryAttribute struct {
+	name  string
+	value driver.Value
+}
+
+// extractQueryAttributes splits queryAttrPrefix-named values out of args,
+// returning the remaining bound parameters and the extracted attributes.
+func extractQueryAttributes(args []driver.NamedValue) ([]driver.NamedValue, []queryAttribute) {
+	var attrs []queryAttribute
+	for _, a := range args {
+		if strings.HasPrefix(a.Name, queryAttrPrefix) {
+			attrs = append(attrs, queryAttribute{
+				name:  strings.TrimPrefix(a.Name, queryAttrPrefix),
+				value: a.Value,
+			})
+		}
+	}
+	if attrs == nil {
+		return args, nil
+	}
+
+	bound := make([]driver.NamedValue, 0, len(args)-len(attrs))
+	for _, a := range args {
+		if !strings.HasPrefix(a.Name, queryAttrPrefix) {
+			bound = append(bound, a)
+		}
+	}
+	return bound, attrs
+}
+
+type queryAttrsCtxKey struct{}
+
+// WithQueryAttributes returns a copy of ctx that carries MySQL query
+// attributes. Conn.QueryContext and Conn.ExecContext read them back and,
+// when the server negotiated CLIENT_QUERY_ATTRIBUTES, send them alongside
+// the query as a COM_QUERY.
+//
+// Prepared statements don't support query attributes yet: Stmt.QueryContext
+// and Stmt.ExecContext return ErrQueryAttributesUnsupported instead of
+// silently dropping them. Use Conn.QueryContext/ExecContext directly if you
+// need both.
+func WithQueryAttributes(ctx context.Context, attrs map[string]string) context.Context {
+	return context.WithValue(ctx, queryAttrsCtxKey{}, attrs)
+}
+
+func queryAttrsFromContext(ctx context.Context) []queryAttribute {
+	m, _ := ctx.Value(queryAttrsCtxKey{}).(map[string]string)
+	if len(m) == 0 {
+		return nil
+	}
+	attrs := make([]queryAttribute, 0, len(m))
+	for name, value := range m {
+		attrs = append(attrs, queryAttribute{name: name, value: value})
+	}
+	return attrs
+}
+
+// writeQueryPacket sends a COM_QUERY packet for query, attaching attrs when
+// the server negotiated CLIENT_QUERY_ATTRIBUTES. Connections that didn't
+// negotiate the capability send the plain query and drop attrs.
+func (mc *mysqlConn) writeQueryPacket(query string, attrs []queryAttribute) error {
+	if len(attrs) == 0 || mc.extCapabilities&clientQueryAttributes == 0 {
+		return mc.writeCommandPacketStr(comQuery, query)
+	}
+	return mc.writeCommandPacketStrWithAttrs(comQuery, query, attrs)
+}
+
+// writeCommandPacketStrWithAttrs writes a COM_QUERY packet carrying query
+// attributes. The wire-format assembly itself lives in
+// appendQueryAttributesPacketBody so it can be unit tested without a live
+// connection; this method only owns the sequence reset and the actual
+// buffer/socket write.
+func (mc *mysqlConn) writeCommandPacketStrWithAttrs(command byte, query string, attrs []queryAttribute) error {
+	mc.resetSequence()
+
+	data, err := mc.buf.takeCompleteBuffer()
+	if err != nil {
+		// This function is only used in queries that are safe to retry.
+		mc.cleanup()
+		return errBadConnNoWrite
+	}
+	data, err = appendQueryAttributesPacketBody(data[:4], command, query, attrs)
+	if err != nil {
+		return err
+	}
+
+	return mc.writePacket(data)
+}
+
+// appendQueryAttributesPacketBody appends a COM_QUERY packet body carrying
+// query attributes to buf (which must already hold the 4-byte packet header
+// reserved by the caller), per the CLIENT_QUERY_ATTRIBUTES wire format:
+// command byte, length-encoded parameter count, a parameter_set_count of 1,
+// a NULL bitmap, the new-params-bound flag, then per-attribute (type, flag,
+// name) triples, the attribute values in the same order, and finally the
+// query text.
+func appendQueryAttributesPacketBody(buf []byte, command byte, query string, attrs []queryAttribute) ([]byte, error) {
+	buf = append(buf, command)
+
+	buf = appendLengthEncodedInteger(buf, uint64(len(attrs)))
+	buf = appendLengthEncodedInteger(buf, 1) // parameter_set_count
+
+	nullBitmap := make([]byte, (len(attrs)+7)/8)
+	for i, attr := range attrs {
+		if attr.value == nil {
+			nullBitmap[i/8] |= 1 << uint(i%8)
+		}
+	}
+	buf = append(buf, nullBitmap...)
+	buf = append(buf, 1) // new-params-bound-flag
+
+	for _, attr := range attrs {
+		fieldType, flag := queryAttributeFieldType(attr.value)
+		buf = append(buf, fieldType, flag)
+		buf = appendLengthEncodedString(buf, attr.name)
+	}
+	for _, attr := range attrs {
+		var err error
+		buf, err = appendQueryAttributeValue(buf, attr.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buf = append(buf, query...)
+
+	return buf, nil
+}
+
+// unsignedFieldFlag is the binary protocol's per-parameter flag byte marking
+// a value as unsigned, as used for uint64 parameters in the prepared
+// statement execute path.
+const unsignedFieldFlag = 0x80
+
+// queryAttributeFieldType picks the binary protocol field type and flag
+// byte used to describe a query attribute's value, mirroring the type
+// dispatch used for bound parameters in the prepared statement execute
+// path.
+func queryAttributeFieldType(v driver.Value) (fieldType, flag byte) {
+	switch v.(type) {
+	case int64:
+		return byte(fieldTypeLongLong), 0
+	case uint64:
+		return byte(fieldTypeLongLong), unsignedFieldFlag
+	case float64:
+		return byte(fieldTypeDouble), 0
+	case bool:
+		return byte(fieldTypeTiny), 0
+	case time.Time:
+		return byte(fieldTypeDateTime), 0
+	default:
+		return byte(fieldTypeVarString), 0
+	}
+}
+
+// appendQueryAttributeValue appends the binary-protocol encoding of v to
+// buf, reusing the same encoders as the prepared statement execute path. A
+// nil value contributes no bytes; it is represented solely by the NULL
+// bitmap.
+func appendQueryAttributeValue(buf []byte, v driver.Value) ([]byte, error) {
+	switch val := v.(type) {
+	case nil:
+		return buf, nil
+	case int64:
+		return binary.LittleEndian.AppendUint64(buf, uint64(val)), nil
+	case uint64:
+		return binary.LittleEndian.AppendUint64(buf, val), nil
+	case float64:
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(val)), nil
+	case bool:
+		if val {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case []byte:
+		return appendLengthEncodedString(buf, string(val)), nil
+	case string:
+		return appendLengthEncodedString(buf, val), nil
+	case time.Time:
+		return appendBinaryDateTime(buf, val)
+	default:
+		return nil, fmt.Errorf("mysql: unsupported query attribute value type %T", v)
+	}
+}
+
+// appendBinaryDateTime appends t in the binary protocol's DATETIME/TIMESTAMP
+// encoding: a length byte followed by year/month/day/hour/minute/second and,
+// when t has sub-second precision, four more bytes of microseconds.
+func appendBinaryDateTime(buf []byte, t time.Time) ([]byte, error) {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	micro := t.Nanosecond() / 1000
+
+	switch {
+	case t.IsZero():
+		return append(buf, 0), nil
+	case micro != 0:
+		return append(buf, 11,
+			byte(year), byte(year>>8),
+			byte(month), byte(day),
+			byte(hour), byte(min), byte(sec),
+			byte(micro), byte(micro>>8), byte(micro>>16), byte(micro>>24),
+		), nil
+	default:
+		return append(buf, 7,
+			byte(year), byte(year>>8),
+			byte(month), byte(day),
+			byte(hour), byte(min), byte(sec),
+		), nil
+	}
+}
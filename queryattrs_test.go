@@ -0,0 +1,318 @@
+// Go MySQL Driver - A MySQL-Driver for Go's database/sql package
+//
+// Copyright 2012 The Go-MySQL-Driver Authors. All rights reserved.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExtractQueryAttributesSplitsReservedNames(t *testing.T) {
+	args := []driver.NamedValue{
+		{Name: "", Ordinal: 1, Value: int64(1)},
+		{Name: "@@attr:trace_id", Ordinal: 2, Value: "abc123"},
+		{Name: "", Ordinal: 3, Value: "hello"},
+	}
+
+	bound, attrs := extractQueryAttributes(args)
+
+	if len(bound) != 2 || bound[0].Value != int64(1) || bound[1].Value != "hello" {
+		t.Fatalf("unexpected bound args: %+v", bound)
+	}
+	if len(attrs) != 1 || attrs[0].name != "trace_id" || attrs[0].value != "abc123" {
+		t.Fatalf("unexpected attrs: %+v", attrs)
+	}
+}
+
+func TestExtractQueryAttributesNoAttrsReturnsInputUnchanged(t *testing.T) {
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+
+	bound, attrs := extractQueryAttributes(args)
+
+	if attrs != nil {
+		t.Fatalf("expected nil attrs, got %+v", attrs)
+	}
+	if len(bound) != 1 || bound[0].Value != int64(1) {
+		t.Fatalf("unexpected bound args: %+v", bound)
+	}
+}
+
+func TestWithQueryAttributesRoundTrip(t *testing.T) {
+	ctx := WithQueryAttributes(context.Background(), map[string]string{"trace_id": "abc123"})
+
+	attrs := queryAttrsFromContext(ctx)
+	if len(attrs) != 1 || attrs[0].name != "trace_id" || attrs[0].value != "abc123" {
+		t.Fatalf("unexpected attrs from context: %+v", attrs)
+	}
+}
+
+func TestQueryAttrsFromContextWithoutAttributesIsNil(t *testing.T) {
+	if attrs := queryAttrsFromContext(context.Background()); attrs != nil {
+		t.Fatalf("expected nil attrs, got %+v", attrs)
+	}
+}
+
+func TestQueryAttributeFieldTypeMarksUint64Unsigned(t *testing.T) {
+	signedType, signedFlag := queryAttributeFieldType(int64(-1))
+	if signedFlag != 0 {
+		t.Fatalf("int64 must not carry the unsigned flag, got %#x", signedFlag)
+	}
+
+	unsignedType, unsignedFlag := queryAttributeFieldType(uint64(1) << 63)
+	if unsignedType != signedType {
+		t.Fatalf("int64 and uint64 should share the same field type, got %#x vs %#x", signedType, unsignedType)
+	}
+	if unsignedFlag != unsignedFieldFlag {
+		t.Fatalf("uint64 must carry the unsigned flag, got %#x", unsignedFlag)
+	}
+}
+
+func TestAppendQueryAttributeValueRoundTripsInt64(t *testing.T) {
+	buf, err := appendQueryAttributeValue(nil, int64(-12345))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := int64(binary.LittleEndian.Uint64(buf)); got != -12345 {
+		t.Fatalf("got %d, want -12345", got)
+	}
+}
+
+func TestAppendQueryAttributeValueRoundTripsUint64(t *testing.T) {
+	const want = uint64(1) << 63
+	buf, err := appendQueryAttributeValue(nil, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := binary.LittleEndian.Uint64(buf); got != want {
+		t.Fatalf("got %#x, want %#x", got, want)
+	}
+}
+
+func TestAppendQueryAttributeValueRoundTripsFloat64(t *testing.T) {
+	const want = 3.14159
+	buf, err := appendQueryAttributeValue(nil, float64(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := math.Float64frombits(binary.LittleEndian.Uint64(buf)); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestAppendQueryAttributeValueRoundTripsBool(t *testing.T) {
+	buf, err := appendQueryAttributeValue(nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buf) != 1 || buf[0] != 1 {
+		t.Fatalf("got %v, want [1]", buf)
+	}
+
+	buf, err = appendQueryAttributeValue(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buf) != 1 || buf[0] != 0 {
+		t.Fatalf("got %v, want [0]", buf)
+	}
+}
+
+// decodeLengthEncodedString is a minimal decoder matching the subset of the
+// length-encoded-string format appendLengthEncodedString produces for short
+// strings (length < 251), enough to round-trip the values used in tests.
+func decodeLengthEncodedString(t *testing.T, buf []byte) string {
+	t.Helper()
+	if len(buf) == 0 {
+		t.Fatal("empty buffer")
+	}
+	n := int(buf[0])
+	if len(buf) < 1+n {
+		t.Fatalf("buffer too short for length-encoded string of length %d: %v", n, buf)
+	}
+	return string(buf[1 : 1+n])
+}
+
+func TestAppendQueryAttributeValueRoundTripsString(t *testing.T) {
+	buf, err := appendQueryAttributeValue(nil, "trace-id-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeLengthEncodedString(t, buf); got != "trace-id-123" {
+		t.Fatalf("got %q, want %q", got, "trace-id-123")
+	}
+}
+
+func TestAppendQueryAttributeValueRoundTripsBytes(t *testing.T) {
+	buf, err := appendQueryAttributeValue(nil, []byte("binary-value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeLengthEncodedString(t, buf); got != "binary-value" {
+		t.Fatalf("got %q, want %q", got, "binary-value")
+	}
+}
+
+func TestAppendQueryAttributeValueNilContributesNoBytes(t *testing.T) {
+	buf, err := appendQueryAttributeValue([]byte("prefix"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "prefix" {
+		t.Fatalf("nil value must not append bytes, got %q", buf)
+	}
+}
+
+func TestAppendQueryAttributeValueRejectsUnsupportedType(t *testing.T) {
+	if _, err := appendQueryAttributeValue(nil, struct{}{}); err == nil {
+		t.Fatal("expected an error for an unsupported attribute value type")
+	}
+}
+
+func TestAppendBinaryDateTimeZeroValueIsSingleZeroByte(t *testing.T) {
+	buf, err := appendBinaryDateTime(nil, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buf) != 1 || buf[0] != 0 {
+		t.Fatalf("got %v, want a single 0x00 length byte", buf)
+	}
+}
+
+func TestAppendBinaryDateTimeRoundTripsWithoutMicroseconds(t *testing.T) {
+	ts := time.Date(2024, time.March, 15, 13, 45, 30, 0, time.UTC)
+	buf, err := appendBinaryDateTime(nil, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf[0] != 7 {
+		t.Fatalf("expected length byte 7, got %d", buf[0])
+	}
+	year := int(binary.LittleEndian.Uint16(buf[1:3]))
+	if year != 2024 || buf[3] != 3 || buf[4] != 15 || buf[5] != 13 || buf[6] != 45 || buf[7] != 30 {
+		t.Fatalf("unexpected encoding: %v", buf)
+	}
+}
+
+func TestAppendBinaryDateTimeRoundTripsWithMicroseconds(t *testing.T) {
+	ts := time.Date(2024, time.March, 15, 13, 45, 30, 123456000, time.UTC)
+	buf, err := appendBinaryDateTime(nil, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf[0] != 11 {
+		t.Fatalf("expected length byte 11, got %d", buf[0])
+	}
+	micro := binary.LittleEndian.Uint32(buf[8:12])
+	if micro != 123456 {
+		t.Fatalf("got microseconds %d, want 123456", micro)
+	}
+}
+
+// TestAppendQueryAttributesPacketBodyAssemblesSpecCompliantPacket builds a
+// full COM_QUERY packet body for two attributes, one of them NULL, and
+// checks every section against the CLIENT_QUERY_ATTRIBUTES wire format: the
+// command byte, the length-encoded parameter count, parameter_set_count=1,
+// the NULL bitmap, the new-params-bound flag, the per-attribute (type,
+// flag, name) triples in attribute order, the values in the same order
+// (skipping the NULL one), and the trailing query text.
+func TestAppendQueryAttributesPacketBodyAssemblesSpecCompliantPacket(t *testing.T) {
+	attrs := []queryAttribute{
+		{name: "trace_id", value: "abc123"},
+		{name: "user_id", value: nil},
+	}
+
+	buf, err := appendQueryAttributesPacketBody(nil, comQuery, "SELECT 1", attrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pos := 0
+	if buf[pos] != comQuery {
+		t.Fatalf("byte 0: got command %#x, want comQuery", buf[pos])
+	}
+	pos++
+
+	if buf[pos] != byte(len(attrs)) {
+		t.Fatalf("parameter count: got %d, want %d", buf[pos], len(attrs))
+	}
+	pos++
+
+	if buf[pos] != 1 {
+		t.Fatalf("parameter_set_count: got %d, want 1", buf[pos])
+	}
+	pos++
+
+	nullBitmapLen := (len(attrs) + 7) / 8
+	nullBitmap := buf[pos : pos+nullBitmapLen]
+	if nullBitmap[0]&(1<<0) != 0 {
+		t.Fatalf("trace_id must not be marked NULL, bitmap %v", nullBitmap)
+	}
+	if nullBitmap[0]&(1<<1) == 0 {
+		t.Fatalf("user_id must be marked NULL, bitmap %v", nullBitmap)
+	}
+	pos += nullBitmapLen
+
+	if buf[pos] != 1 {
+		t.Fatalf("new-params-bound-flag: got %d, want 1", buf[pos])
+	}
+	pos++
+
+	wantType, wantFlag := queryAttributeFieldType("abc123")
+	if buf[pos] != wantType || buf[pos+1] != wantFlag {
+		t.Fatalf("trace_id type/flag: got (%#x, %#x), want (%#x, %#x)", buf[pos], buf[pos+1], wantType, wantFlag)
+	}
+	pos += 2
+	name := decodeLengthEncodedString(t, buf[pos:])
+	if name != "trace_id" {
+		t.Fatalf("first attribute name: got %q, want %q", name, "trace_id")
+	}
+	pos += 1 + len(name)
+
+	wantType, wantFlag = queryAttributeFieldType(nil)
+	if buf[pos] != wantType || buf[pos+1] != wantFlag {
+		t.Fatalf("user_id type/flag: got (%#x, %#x), want (%#x, %#x)", buf[pos], buf[pos+1], wantType, wantFlag)
+	}
+	pos += 2
+	name = decodeLengthEncodedString(t, buf[pos:])
+	if name != "user_id" {
+		t.Fatalf("second attribute name: got %q, want %q", name, "user_id")
+	}
+	pos += 1 + len(name)
+
+	value := decodeLengthEncodedString(t, buf[pos:])
+	if value != "abc123" {
+		t.Fatalf("trace_id value: got %q, want %q", value, "abc123")
+	}
+	pos += 1 + len(value)
+
+	if query := string(buf[pos:]); query != "SELECT 1" {
+		t.Fatalf("trailing query: got %q, want %q", query, "SELECT 1")
+	}
+}
+
+// TestAppendQueryAttributesPacketBodyNoAttrsStillWritesHeader exercises the
+// zero-attribute edge case: writeQueryPacket never calls through to this
+// function when there are no attrs, but the function itself should still
+// produce a spec-shaped (empty) header rather than panicking.
+func TestAppendQueryAttributesPacketBodyNoAttrsStillWritesHeader(t *testing.T) {
+	buf, err := appendQueryAttributesPacketBody(nil, comQuery, "SELECT 1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{comQuery, 0, 1, 1}
+	want = append(want, "SELECT 1"...)
+	if string(buf) != string(want) {
+		t.Fatalf("got %v, want %v", buf, want)
+	}
+}